@@ -1,14 +1,20 @@
 package doge
 
-const blockMinVersionAuxpow = 0x00620002
+import "github.com/btcsuite/btcd/wire"
+
 const blockVersionFlagAuxpow = 0x00000100
 
 const versionAuxPow = 1 << 8
 
+// IsAuxPoWBlockVersion reports whether version signals that a block header
+// carries an AuxPoW commitment. The canonical definition lives in
+// wire.IsAuxPoWBlockVersion, since BlockHeader's wire encoding needs it to
+// decide whether to read/write AuxPoW bytes; this just re-exports it for
+// doge's own consensus-level callers.
 func IsAuxPoWBlockVersion(version int32) bool {
-	return version >= blockMinVersionAuxpow && (version & blockVersionFlagAuxpow) > 0
+	return wire.IsAuxPoWBlockVersion(version)
 }
 
 func GetBaseVersion(version int32) int32 {
 	return version % versionAuxPow
-}
\ No newline at end of file
+}
@@ -0,0 +1,158 @@
+package doge
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// dogeChainID is Dogecoin's registered merged-mining chain ID.  It is the
+// same value encoded in the upper 16 bits of wire's minAuxPowBlockVersion.
+const dogeChainID = 0x0062
+
+// MaxAuxPowMerkleSize is the largest merkle-tree size exponent a merged
+// mining commitment is allowed to claim.  Anything larger could be used to
+// force absurdly expensive (or overflowing) chain-index arithmetic.
+// Registries that assign merkle tree sizes, such as
+// mergedmining.AuxChainRegistry, must stay within this same bound so that
+// what they commit to can still be verified here.
+const MaxAuxPowMerkleSize = 30
+
+// AuxPowMagic is the tag merge-mining software embeds in the parent
+// coinbase's signature script immediately before the 32-byte merged-mining
+// merkle root.
+var AuxPowMagic = []byte{0xfa, 0xbe, 'm', 'm'}
+
+var (
+	// ErrAuxPowParentIsAux is returned when the parent block header is
+	// itself flagged as an AuxPoW block, or claims Dogecoin's own chain
+	// ID, either of which would allow a block to merge-mine on top of
+	// itself.
+	ErrAuxPowParentIsAux = errors.New("doge: parent block must not be an auxiliary chain of this one")
+
+	// ErrAuxPowCoinbaseRoot is returned when the parent coinbase's merkle
+	// branch does not hash up to the parent block's merkle root.
+	ErrAuxPowCoinbaseRoot = errors.New("doge: parent coinbase merkle branch does not connect to parent block merkle root")
+
+	// ErrAuxPowMagicNotFound is returned when the merged-mining magic
+	// bytes are absent from the parent coinbase's signature script.
+	ErrAuxPowMagicNotFound = errors.New("doge: merged-mining magic not found in parent coinbase")
+
+	// ErrAuxPowMagicMultiple is returned when the merged-mining magic
+	// bytes appear more than once in the parent coinbase's signature
+	// script.
+	ErrAuxPowMagicMultiple = errors.New("doge: merged-mining magic found more than once in parent coinbase")
+
+	// ErrAuxPowMagicTooClose is returned when there isn't enough room
+	// left in the signature script, after the magic, for the root,
+	// nonce and merkle size fields the commitment requires.
+	ErrAuxPowMagicTooClose = errors.New("doge: merged-mining commitment runs past the end of the signature script")
+
+	// ErrAuxPowRootMismatch is returned when the merged-mining merkle
+	// root recomputed from BlockchainBranch and the auxiliary block hash
+	// does not match the root committed to in the parent coinbase.
+	ErrAuxPowRootMismatch = errors.New("doge: merged-mining merkle root does not match coinbase commitment")
+
+	// ErrAuxPowMerkleSize is returned when the committed merkle size
+	// exponent is out of range.
+	ErrAuxPowMerkleSize = errors.New("doge: merged-mining merkle tree size out of range")
+
+	// ErrAuxPowWrongIndex is returned when the auxiliary chain's slot in
+	// the merged-mining tree does not match the one derived from the
+	// committed nonce and chain count.
+	ErrAuxPowWrongIndex = errors.New("doge: merged-mining branch index does not match auxiliary chain slot")
+)
+
+// VerifyAuxPoW validates that auxHeader is a well-formed merged-mining proof
+// that auxBlockHash belongs to the auxiliary chain identified by
+// auxChainID, enforcing the Dogecoin/Namecoin AuxPoW consensus rules.
+func VerifyAuxPoW(auxHeader *wire.AuxBlockHeader, auxChainID int32, auxBlockHash chainhash.Hash) error {
+	// (5) The parent block must not itself be flagged as an AuxPoW block,
+	// or claim Dogecoin's own chain ID, either of which would allow a
+	// block to merge-mine on top of itself indefinitely.
+	if uint32(auxHeader.ParentBlock.Version)&blockVersionFlagAuxpow != 0 {
+		return ErrAuxPowParentIsAux
+	}
+	if int32(uint32(auxHeader.ParentBlock.Version)>>16) == dogeChainID {
+		return ErrAuxPowParentIsAux
+	}
+
+	// (1) The parent coinbase must connect to the parent block's merkle
+	// root via CoinbaseBranch.
+	coinbaseHash := auxHeader.ParentCoinbase.TxHash()
+	coinbaseRoot := walkMerkleBranch(coinbaseHash, auxHeader.CoinbaseBranch)
+	if coinbaseRoot != auxHeader.ParentBlock.MerkleRoot {
+		return ErrAuxPowCoinbaseRoot
+	}
+
+	// (2) Locate the merged-mining commitment in the first coinbase
+	// input's signature script.
+	if len(auxHeader.ParentCoinbase.TxIn) == 0 {
+		return ErrAuxPowMagicNotFound
+	}
+	script := auxHeader.ParentCoinbase.TxIn[0].SignatureScript
+
+	idx := bytes.Index(script, AuxPowMagic)
+	if idx == -1 {
+		return ErrAuxPowMagicNotFound
+	}
+	if bytes.Index(script[idx+1:], AuxPowMagic) != -1 {
+		return ErrAuxPowMagicMultiple
+	}
+	// After the magic comes the 32-byte root, a 4-byte nonce and a
+	// 4-byte merkle size -- require the "loose" rule that there are at
+	// least that many bytes left in the script, regardless of whatever
+	// arbitrary suffix the miner appended after that.
+	commitStart := idx + len(AuxPowMagic)
+	if commitStart+32+4+4 > len(script) {
+		return ErrAuxPowMagicTooClose
+	}
+	var committedRoot chainhash.Hash
+	copy(committedRoot[:], script[commitStart:commitStart+32])
+	rand := binary.LittleEndian.Uint32(script[commitStart+32 : commitStart+36])
+	merkleSize := binary.LittleEndian.Uint32(script[commitStart+36 : commitStart+40])
+
+	// (4) Bound the tree size and confirm the auxiliary chain sits in
+	// its expected slot.
+	if merkleSize > MaxAuxPowMerkleSize {
+		return ErrAuxPowMerkleSize
+	}
+	chainCount := int32(1) << merkleSize
+	expectedIndex := (int32(rand)*chainCount + auxChainID) % chainCount
+	if auxHeader.BlockchainBranch.BranchSidesBitmask != expectedIndex {
+		return ErrAuxPowWrongIndex
+	}
+
+	// (3) Recompute the merged-mining merkle root from BlockchainBranch
+	// and the auxiliary block's own hash, and require it match what was
+	// committed to in the coinbase.
+	auxRoot := walkMerkleBranch(auxBlockHash, auxHeader.BlockchainBranch)
+	if auxRoot != committedRoot {
+		return ErrAuxPowRootMismatch
+	}
+
+	return nil
+}
+
+// walkMerkleBranch recomputes a merkle root by combining hash with each
+// link in branch in turn, double-SHA256ing the pair at every step.  Bit i
+// of branch.BranchSidesBitmask selects which side of the pair the running
+// hash goes on at level i: zero means the running hash goes on the left
+// and the branch hash on the right, one means the reverse.
+func walkMerkleBranch(hash chainhash.Hash, branch wire.MerkleBranch) chainhash.Hash {
+	for i, link := range branch.LinkHashes {
+		var combined [chainhash.HashSize * 2]byte
+		if (branch.BranchSidesBitmask>>uint(i))&1 == 1 {
+			copy(combined[:chainhash.HashSize], link[:])
+			copy(combined[chainhash.HashSize:], hash[:])
+		} else {
+			copy(combined[:chainhash.HashSize], hash[:])
+			copy(combined[chainhash.HashSize:], link[:])
+		}
+		hash = chainhash.DoubleHashH(combined[:])
+	}
+	return hash
+}
@@ -0,0 +1,229 @@
+package doge
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// buildAuxPoW constructs a minimally valid AuxBlockHeader merge-mining
+// auxBlockHash into a parent coinbase, with single-leaf (branch-less)
+// merkle trees on both sides. Real Dogecoin AuxPoW blocks use multi-level
+// branches; see buildRealisticAuxPoW.
+func buildAuxPoW(auxChainID int32, auxBlockHash chainhash.Hash) *wire.AuxBlockHeader {
+	var rand, merkleSize uint32 = 0, 0
+
+	script := make([]byte, 0, len(AuxPowMagic)+32+4+4)
+	script = append(script, AuxPowMagic...)
+	script = append(script, auxBlockHash[:]...)
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], rand)
+	script = append(script, tmp[:]...)
+	binary.LittleEndian.PutUint32(tmp[:], merkleSize)
+	script = append(script, tmp[:]...)
+
+	coinbase := wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: script,
+		}},
+		TxOut: []*wire.TxOut{{}},
+	}
+	coinbaseHash := coinbase.TxHash()
+
+	return &wire.AuxBlockHeader{
+		ParentCoinbase: coinbase,
+		CoinbaseBranch: wire.MerkleBranch{},
+		BlockchainBranch: wire.MerkleBranch{
+			BranchSidesBitmask: 0,
+		},
+		ParentBlock: wire.ParentBlock{
+			Version:    0x00000002, // non-aux, non-Dogecoin chain ID
+			MerkleRoot: coinbaseHash,
+		},
+	}
+}
+
+// buildRealisticAuxPoW constructs an AuxBlockHeader whose CoinbaseBranch and
+// BlockchainBranch both span multiple levels, rather than the single-leaf,
+// branch-less trees buildAuxPoW produces. This is the shape real Dogecoin
+// AuxPoW blocks take once the parent block holds more than one transaction
+// and more than one chain is merge-mined together, as has been true of every
+// Dogecoin block since merged mining went live around height 371337: the
+// coinbase sits at index 0 of a multi-transaction parent block, and the
+// auxiliary chain occupies one slot of a multi-chain merged-mining tree.
+func buildRealisticAuxPoW(auxChainID int32, auxBlockHash chainhash.Hash) *wire.AuxBlockHeader {
+	// The auxiliary chain's merkle branch: a 2-level tree (4 slots), with
+	// auxChainID landing in slot 3. Real sibling hashes stand in for the
+	// commitments of the other merge-mined chains sharing this tree.
+	blockchainBranch := wire.MerkleBranch{
+		LinkHashes: []*chainhash.Hash{
+			{0x11, 0x11, 0x11},
+			{0x22, 0x22, 0x22},
+		},
+		BranchSidesBitmask: 3, // 0b11: link on the left at both levels
+	}
+	committedRoot := walkMerkleBranch(auxBlockHash, blockchainBranch)
+
+	var rand uint32 = 0
+	const merkleSize uint32 = 2 // 2^2 = 4 chain slots
+	// auxChainID must land in the same slot the branch above was built
+	// for: (rand*chainCount + auxChainID) % chainCount == 3.
+	chainCount := int32(1) << merkleSize
+	slot := (rand*uint32(chainCount) + uint32(auxChainID)) % uint32(chainCount)
+	if slot != uint32(blockchainBranch.BranchSidesBitmask) {
+		panic("buildRealisticAuxPoW: auxChainID does not land in the fixture's slot")
+	}
+
+	script := make([]byte, 0, len(AuxPowMagic)+32+4+4)
+	script = append(script, AuxPowMagic...)
+	script = append(script, committedRoot[:]...)
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], rand)
+	script = append(script, tmp[:]...)
+	binary.LittleEndian.PutUint32(tmp[:], merkleSize)
+	script = append(script, tmp[:]...)
+
+	coinbase := wire.MsgTx{
+		Version: 1,
+		TxIn: []*wire.TxIn{{
+			SignatureScript: script,
+		}},
+		TxOut: []*wire.TxOut{{}},
+	}
+	coinbaseHash := coinbase.TxHash()
+
+	// The parent block's merkle tree: the coinbase sits at index 0 of a
+	// 2-level (4-transaction) tree, so its sibling hash is on the right
+	// at every level.
+	coinbaseBranch := wire.MerkleBranch{
+		LinkHashes: []*chainhash.Hash{
+			{0x33, 0x33, 0x33},
+			{0x44, 0x44, 0x44},
+		},
+		BranchSidesBitmask: 0,
+	}
+	parentRoot := walkMerkleBranch(coinbaseHash, coinbaseBranch)
+
+	return &wire.AuxBlockHeader{
+		ParentCoinbase:   coinbase,
+		CoinbaseBranch:   coinbaseBranch,
+		BlockchainBranch: blockchainBranch,
+		ParentBlock: wire.ParentBlock{
+			Version:    0x00000002, // non-aux, non-Dogecoin chain ID
+			MerkleRoot: parentRoot,
+		},
+	}
+}
+
+func TestVerifyAuxPoWRealisticMultiLevelBranches(t *testing.T) {
+	auxChainID := int32(3)
+	auxHash := chainhash.Hash{0x01, 0x02, 0x03}
+
+	h := buildRealisticAuxPoW(auxChainID, auxHash)
+	if err := VerifyAuxPoW(h, auxChainID, auxHash); err != nil {
+		t.Fatalf("VerifyAuxPoW() error = %v", err)
+	}
+}
+
+func TestVerifyAuxPoW(t *testing.T) {
+	auxHash := chainhash.Hash{0x01, 0x02, 0x03}
+
+	tests := []struct {
+		name    string
+		mutate  func(h *wire.AuxBlockHeader)
+		wantErr error
+	}{
+		{
+			name:    "valid auxpow",
+			mutate:  func(h *wire.AuxBlockHeader) {},
+			wantErr: nil,
+		},
+		{
+			name: "parent is itself an aux chain",
+			mutate: func(h *wire.AuxBlockHeader) {
+				h.ParentBlock.Version = dogeChainID<<16 | blockVersionFlagAuxpow
+			},
+			wantErr: ErrAuxPowParentIsAux,
+		},
+		{
+			name: "parent is flagged auxpow under a different chain ID",
+			mutate: func(h *wire.AuxBlockHeader) {
+				h.ParentBlock.Version = 7<<16 | blockVersionFlagAuxpow
+			},
+			wantErr: ErrAuxPowParentIsAux,
+		},
+		{
+			name: "coinbase branch does not connect to merkle root",
+			mutate: func(h *wire.AuxBlockHeader) {
+				h.ParentBlock.MerkleRoot = chainhash.Hash{0xff}
+			},
+			wantErr: ErrAuxPowCoinbaseRoot,
+		},
+		{
+			name: "magic not present",
+			mutate: func(h *wire.AuxBlockHeader) {
+				h.ParentCoinbase.TxIn[0].SignatureScript = []byte{0x01, 0x02}
+				h.ParentBlock.MerkleRoot = h.ParentCoinbase.TxHash()
+			},
+			wantErr: ErrAuxPowMagicNotFound,
+		},
+		{
+			name: "magic appears twice",
+			mutate: func(h *wire.AuxBlockHeader) {
+				script := h.ParentCoinbase.TxIn[0].SignatureScript
+				script = append(script, AuxPowMagic...)
+				h.ParentCoinbase.TxIn[0].SignatureScript = script
+				h.ParentBlock.MerkleRoot = h.ParentCoinbase.TxHash()
+			},
+			wantErr: ErrAuxPowMagicMultiple,
+		},
+		{
+			name: "commitment runs past end of script",
+			mutate: func(h *wire.AuxBlockHeader) {
+				h.ParentCoinbase.TxIn[0].SignatureScript = AuxPowMagic
+				h.ParentBlock.MerkleRoot = h.ParentCoinbase.TxHash()
+			},
+			wantErr: ErrAuxPowMagicTooClose,
+		},
+		{
+			name: "merkle size out of range",
+			mutate: func(h *wire.AuxBlockHeader) {
+				script := h.ParentCoinbase.TxIn[0].SignatureScript
+				binary.LittleEndian.PutUint32(script[len(script)-4:], MaxAuxPowMerkleSize+1)
+				h.ParentBlock.MerkleRoot = h.ParentCoinbase.TxHash()
+			},
+			wantErr: ErrAuxPowMerkleSize,
+		},
+		{
+			name: "branch index does not match expected slot",
+			mutate: func(h *wire.AuxBlockHeader) {
+				h.BlockchainBranch.BranchSidesBitmask = 1
+			},
+			wantErr: ErrAuxPowWrongIndex,
+		},
+		{
+			name: "root mismatch",
+			mutate: func(h *wire.AuxBlockHeader) {
+				script := h.ParentCoinbase.TxIn[0].SignatureScript
+				wrongRoot := chainhash.Hash{0xaa}
+				copy(script[len(AuxPowMagic):len(AuxPowMagic)+32], wrongRoot[:])
+				h.ParentBlock.MerkleRoot = h.ParentCoinbase.TxHash()
+			},
+			wantErr: ErrAuxPowRootMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := buildAuxPoW(0, auxHash)
+			tc.mutate(h)
+			err := VerifyAuxPoW(h, 0, auxHash)
+			if err != tc.wantErr {
+				t.Fatalf("VerifyAuxPoW() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
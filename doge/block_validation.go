@@ -0,0 +1,32 @@
+package doge
+
+import (
+	"errors"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrUnexpectedDifficulty is returned when a header's Bits field does not
+// match what CalcNextRequiredDifficulty computes for the chain it extends.
+var ErrUnexpectedDifficulty = errors.New("doge: block difficulty bits do not match the required value")
+
+// ValidateBlockHeader checks that header is valid to extend lastNode at
+// newBlockTime: its Bits field must match what CalcNextRequiredDifficulty
+// computes for this chain, and its proof of work must satisfy that target,
+// following into its AuxPoW parent when header signals merged mining. This
+// is the single entry point a Dogecoin-style block acceptance path calls
+// in place of Bitcoin's own checkProofOfWork/calcNextRequiredDifficulty
+// steps.
+func ValidateBlockHeader(header *wire.BlockHeader, lastNode HeaderCtx, newBlockTime time.Time, params *chaincfg.Params) error {
+	requiredBits, err := CalcNextRequiredDifficulty(lastNode, newBlockTime, params)
+	if err != nil {
+		return err
+	}
+	if header.Bits != requiredBits {
+		return ErrUnexpectedDifficulty
+	}
+
+	return CheckBlockProofOfWork(header, header.Bits, params.PowLimit)
+}
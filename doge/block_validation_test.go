@@ -0,0 +1,69 @@
+package doge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestValidateBlockHeader(t *testing.T) {
+	lastNode := newChain(10, time.Minute, digishieldBlockHeight-1000, 0x1e0ffff0)
+	newBlockTime := lastNode.Timestamp().Add(time.Minute)
+
+	t.Run("matching bits and satisfied PoW is accepted", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version:   0x00000002,
+			Timestamp: time.Unix(1500000000, 0),
+			Bits:      0x1e0ffff0,
+			Nonce:     3362412,
+		}
+
+		if err := ValidateBlockHeader(header, lastNode, newBlockTime, testParams); err != nil {
+			t.Fatalf("ValidateBlockHeader() error = %v", err)
+		}
+	})
+
+	t.Run("bits that don't match the required difficulty are rejected", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version: 0x00000002,
+			Bits:    0x1d00ffff,
+		}
+
+		err := ValidateBlockHeader(header, lastNode, newBlockTime, testParams)
+		if err != ErrUnexpectedDifficulty {
+			t.Fatalf("ValidateBlockHeader() err = %v, want %v", err, ErrUnexpectedDifficulty)
+		}
+	})
+
+	t.Run("auxpow header follows into its parent block", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version: dogeChainID<<16 | blockVersionFlagAuxpow,
+			Bits:    0x1e0ffff0,
+			AuxData: &wire.AuxBlockHeader{
+				ParentBlock: wire.ParentBlock{
+					Version:   0x00000002,
+					Timestamp: time.Unix(1500000000, 0),
+					Bits:      0x1e0ffff0,
+					Nonce:     3362412,
+				},
+			},
+		}
+
+		if err := ValidateBlockHeader(header, lastNode, newBlockTime, testParams); err != nil {
+			t.Fatalf("ValidateBlockHeader() error = %v", err)
+		}
+	})
+
+	t.Run("auxpow header missing AuxData is rejected", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version: dogeChainID<<16 | blockVersionFlagAuxpow,
+			Bits:    0x1e0ffff0,
+		}
+
+		err := ValidateBlockHeader(header, lastNode, newBlockTime, testParams)
+		if err != ErrAuxPoWMissingAuxData {
+			t.Fatalf("ValidateBlockHeader() err = %v, want %v", err, ErrAuxPoWMissingAuxData)
+		}
+	})
+}
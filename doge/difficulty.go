@@ -0,0 +1,139 @@
+package doge
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+const (
+	// blocksPerRetarget is the number of blocks between difficulty
+	// retargets under the pre-Digishield rules, inherited unchanged from
+	// Bitcoin/Litecoin.
+	blocksPerRetarget = 240
+
+	// preDigishieldTargetTimespan is the pre-Digishield retarget window,
+	// in seconds: four hours' worth of one-minute blocks.
+	preDigishieldTargetTimespan = 4 * 60 * 60
+
+	// digishieldRetargetTimespan is the target spacing, in seconds, that
+	// Digishield retargets toward on every block.
+	digishieldRetargetTimespan = 60
+
+	// allowMinDifficultyTimeout is how far newBlockTime must be past the
+	// last block's timestamp before a chain with ReduceMinDifficulty set
+	// (testnet) is allowed to reset to the network's minimum difficulty.
+	allowMinDifficultyTimeout = 20 * time.Minute
+)
+
+// HeaderCtx is the minimal view of a block header and its ancestry that
+// CalcNextRequiredDifficulty needs. Callers adapt the node type they track
+// (blockchain's blockNode, or the lighter-weight nodes used by headers-first
+// sync) to it, so both can share this routine.
+type HeaderCtx interface {
+	// Height returns the height of this header.
+	Height() int32
+
+	// Bits returns the difficulty bits of this header.
+	Bits() uint32
+
+	// Timestamp returns this header's timestamp.
+	Timestamp() time.Time
+
+	// RelativeAncestorCtx returns the ancestor that is distance blocks
+	// before this one, or false if no such ancestor exists.
+	RelativeAncestorCtx(distance int32) (HeaderCtx, bool)
+}
+
+// CalcNextRequiredDifficulty calculates the required difficulty for a
+// block following lastNode, given newBlockTime as that block's timestamp.
+// Depending on lastNode's height, it applies the pre-Digishield retarget
+// rule (every blocksPerRetarget blocks, 4-hour window), or Dogecoin's
+// Digishield rule (every block, dampened, 60-second window). Chains with
+// params.ReduceMinDifficulty set may also reset to the minimum difficulty
+// when newBlockTime falls too far after lastNode's timestamp.
+//
+// ValidateBlockHeader is the entry point that calls this during block
+// acceptance, with lastNode adapting whatever node type the caller already
+// tracks (blockNode or a headers-first equivalent) to HeaderCtx.
+func CalcNextRequiredDifficulty(lastNode HeaderCtx, newBlockTime time.Time, params *chaincfg.Params) (uint32, error) {
+	if params.ReduceMinDifficulty && newBlockTime.After(lastNode.Timestamp().Add(allowMinDifficultyTimeout)) {
+		return blockchain.BigToCompact(params.PowLimit), nil
+	}
+
+	if lastNode.Height()+1 < digishieldBlockHeight {
+		return calcNextRequiredDifficultyPreDigishield(lastNode, params)
+	}
+	return calcNextRequiredDifficultyDigishield(lastNode, params)
+}
+
+// calcNextRequiredDifficultyPreDigishield implements the original
+// 240-block retarget window with the classic 4x/0.25x clamp.
+func calcNextRequiredDifficultyPreDigishield(lastNode HeaderCtx, params *chaincfg.Params) (uint32, error) {
+	nextHeight := lastNode.Height() + 1
+	if nextHeight%blocksPerRetarget != 0 {
+		return lastNode.Bits(), nil
+	}
+
+	firstNode, ok := lastNode.RelativeAncestorCtx(blocksPerRetarget - 1)
+	if !ok {
+		return 0, fmt.Errorf("doge: unable to obtain ancestor %d blocks back from height %d",
+			blocksPerRetarget-1, lastNode.Height())
+	}
+
+	actualTimespan := lastNode.Timestamp().Sub(firstNode.Timestamp())
+	targetTimespan := preDigishieldTargetTimespan * time.Second
+	adjustedTimespan := clampTimespan(actualTimespan, targetTimespan/4, targetTimespan*4)
+
+	return rescaleTarget(lastNode.Bits(), adjustedTimespan, targetTimespan, params), nil
+}
+
+// calcNextRequiredDifficultyDigishield implements Dogecoin's Digishield
+// retarget: every block, dampened by 7/8ths, clamped to [-25%, +50%] of
+// the 60-second target.
+func calcNextRequiredDifficultyDigishield(lastNode HeaderCtx, params *chaincfg.Params) (uint32, error) {
+	firstNode, ok := lastNode.RelativeAncestorCtx(1)
+	if !ok {
+		// No prior block to diff against (genesis); keep bits as-is.
+		return lastNode.Bits(), nil
+	}
+
+	targetTimespan := digishieldRetargetTimespan * time.Second
+	actualTimespan := lastNode.Timestamp().Sub(firstNode.Timestamp())
+	damped := targetTimespan + (actualTimespan-targetTimespan)/8
+
+	adjustedTimespan := clampTimespan(damped, targetTimespan-targetTimespan/4, targetTimespan+targetTimespan/2)
+
+	return rescaleTarget(lastNode.Bits(), adjustedTimespan, targetTimespan, params), nil
+}
+
+// clampTimespan restricts actual to the inclusive range [min, max].
+func clampTimespan(actual, min, max time.Duration) time.Duration {
+	switch {
+	case actual < min:
+		return min
+	case actual > max:
+		return max
+	default:
+		return actual
+	}
+}
+
+// rescaleTarget scales the target implied by bits by actualTimespan /
+// retargetTimespan, capping the result at params.PowLimit, and re-encodes
+// it in compact form.
+func rescaleTarget(bits uint32, actualTimespan, retargetTimespan time.Duration, params *chaincfg.Params) uint32 {
+	oldTarget := blockchain.CompactToBig(bits)
+
+	newTarget := new(big.Int).Mul(oldTarget, big.NewInt(int64(actualTimespan)))
+	newTarget.Div(newTarget, big.NewInt(int64(retargetTimespan)))
+
+	if newTarget.Cmp(params.PowLimit) > 0 {
+		newTarget.Set(params.PowLimit)
+	}
+
+	return blockchain.BigToCompact(newTarget)
+}
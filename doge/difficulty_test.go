@@ -0,0 +1,117 @@
+package doge
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// testHeaderCtx is a minimal, linked-list backed HeaderCtx used to exercise
+// CalcNextRequiredDifficulty without needing a real block index.
+type testHeaderCtx struct {
+	height    int32
+	bits      uint32
+	timestamp time.Time
+	parent    *testHeaderCtx
+}
+
+func (n *testHeaderCtx) Height() int32        { return n.height }
+func (n *testHeaderCtx) Bits() uint32         { return n.bits }
+func (n *testHeaderCtx) Timestamp() time.Time { return n.timestamp }
+
+func (n *testHeaderCtx) RelativeAncestorCtx(distance int32) (HeaderCtx, bool) {
+	cur := n
+	for i := int32(0); i < distance; i++ {
+		if cur.parent == nil {
+			return nil, false
+		}
+		cur = cur.parent
+	}
+	return cur, true
+}
+
+func newChain(count int, spacing time.Duration, startHeight int32, bits uint32) *testHeaderCtx {
+	var node *testHeaderCtx
+	ts := time.Unix(1386325540, 0)
+	for i := 0; i < count; i++ {
+		node = &testHeaderCtx{
+			height:    startHeight + int32(i),
+			bits:      bits,
+			timestamp: ts,
+			parent:    node,
+		}
+		ts = ts.Add(spacing)
+	}
+	return node
+}
+
+var testParams = &chaincfg.Params{
+	// 2^236 - 1, so the 0x1e0ffff0 bits the tests use stay within it.
+	PowLimit: func() *big.Int {
+		limit := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 236), big.NewInt(1))
+		return limit
+	}(),
+}
+
+func TestCalcNextRequiredDifficultyPreDigishieldNoRetarget(t *testing.T) {
+	lastNode := newChain(10, time.Minute, digishieldBlockHeight-1000, 0x1e0ffff0)
+
+	got, err := CalcNextRequiredDifficulty(lastNode, lastNode.Timestamp().Add(time.Minute), testParams)
+	if err != nil {
+		t.Fatalf("CalcNextRequiredDifficulty() error = %v", err)
+	}
+	if got != lastNode.Bits() {
+		t.Fatalf("expected unchanged bits %08x between retarget points, got %08x", lastNode.Bits(), got)
+	}
+}
+
+func TestCalcNextRequiredDifficultyPreDigishieldRetarget(t *testing.T) {
+	startHeight := digishieldBlockHeight - 10000
+	// Make nextHeight a retarget point.
+	startHeight -= startHeight % blocksPerRetarget
+	lastNode := newChain(blocksPerRetarget, time.Minute, startHeight, 0x1e0ffff0)
+
+	got, err := CalcNextRequiredDifficulty(lastNode, lastNode.Timestamp().Add(time.Minute), testParams)
+	if err != nil {
+		t.Fatalf("CalcNextRequiredDifficulty() error = %v", err)
+	}
+	// The timespan is measured from the ancestor blocksPerRetarget-1 back,
+	// so one-minute spacing spans 239 minutes against the 240-minute
+	// window and the target shrinks by 1/240: 0x0ffff0*239/240 = 0x0feedf.
+	const want = 0x1e0feedf
+	if got != want {
+		t.Fatalf("expected 239-minute retarget to give bits %08x, got %08x", uint32(want), got)
+	}
+}
+
+func TestCalcNextRequiredDifficultyDigishield(t *testing.T) {
+	lastNode := newChain(5, digishieldRetargetTimespan*time.Second, digishieldBlockHeight+10, 0x1e0ffff0)
+
+	got, err := CalcNextRequiredDifficulty(lastNode, lastNode.Timestamp().Add(digishieldRetargetTimespan*time.Second), testParams)
+	if err != nil {
+		t.Fatalf("CalcNextRequiredDifficulty() error = %v", err)
+	}
+	if got != lastNode.Bits() {
+		t.Fatalf("expected on-target digishield retarget to leave bits %08x unchanged, got %08x", lastNode.Bits(), got)
+	}
+}
+
+func TestCalcNextRequiredDifficultyAllowMinDifficulty(t *testing.T) {
+	lastNode := newChain(3, digishieldRetargetTimespan*time.Second, digishieldBlockHeight+10, 0x1e0ffff0)
+
+	params := *testParams
+	params.ReduceMinDifficulty = true
+
+	newBlockTime := lastNode.Timestamp().Add(allowMinDifficultyTimeout + time.Second)
+	got, err := CalcNextRequiredDifficulty(lastNode, newBlockTime, &params)
+	if err != nil {
+		t.Fatalf("CalcNextRequiredDifficulty() error = %v", err)
+	}
+	want := blockchain.BigToCompact(params.PowLimit)
+	if got != want {
+		t.Fatalf("expected min-difficulty reset to %08x, got %08x", want, got)
+	}
+}
@@ -0,0 +1,285 @@
+// Package mergedmining lets a node host merged mining for more than one
+// auxiliary chain at once: each chain registers itself, submits its
+// current candidate block, and gets back the merkle branch that proves
+// its slot in the shared merged-mining root this node's own block
+// commits to.
+package mergedmining
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/doge"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrChainAlreadyRegistered is returned by RegisterChain when
+	// chainID has already been registered.
+	ErrChainAlreadyRegistered = errors.New("mergedmining: chain ID is already registered")
+
+	// ErrChainNotRegistered is returned when an operation references a
+	// chain ID that hasn't been registered.
+	ErrChainNotRegistered = errors.New("mergedmining: chain ID is not registered")
+
+	// ErrNoRegisteredChains is returned by BuildAuxRoot when there are
+	// no registered chains to build a tree over.
+	ErrNoRegisteredChains = errors.New("mergedmining: no auxiliary chains are registered")
+
+	// ErrTooManyChains is returned by RegisterChain when adding the chain
+	// would force the merged-mining tree past doge.MaxAuxPowMerkleSize,
+	// a size doge.VerifyAuxPoW will never accept.
+	ErrTooManyChains = errors.New("mergedmining: registering this chain would exceed the maximum merged-mining tree size")
+)
+
+// ChainInfo describes a single registered auxiliary chain.
+type ChainInfo struct {
+	ChainID   int32
+	Name      string
+	Candidate chainhash.Hash
+}
+
+// AuxRoot is the merged-mining commitment derived from every registered
+// chain's current candidate block.
+type AuxRoot struct {
+	Root       chainhash.Hash
+	MerkleSize uint32
+}
+
+type registeredChain struct {
+	name      string
+	candidate chainhash.Hash
+}
+
+// AuxChainRegistry tracks auxiliary chains registered for merged mining
+// under this node acting as the primary chain, and computes the
+// merged-mining merkle tree over their current candidate blocks.
+type AuxChainRegistry struct {
+	mtx    sync.RWMutex
+	chains map[int32]*registeredChain
+	store  Store
+}
+
+// NewAuxChainRegistry returns a registry populated from store, or an empty
+// one if store has nothing saved yet.
+func NewAuxChainRegistry(store Store) (*AuxChainRegistry, error) {
+	r := &AuxChainRegistry{
+		chains: make(map[int32]*registeredChain),
+		store:  store,
+	}
+
+	saved, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for chainID, name := range saved {
+		r.chains[chainID] = &registeredChain{name: name}
+	}
+
+	return r, nil
+}
+
+// RegisterChain adds a new auxiliary chain to the registry and persists
+// the updated chain set.
+func (r *AuxChainRegistry) RegisterChain(chainID int32, name string) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if _, ok := r.chains[chainID]; ok {
+		return ErrChainAlreadyRegistered
+	}
+	r.chains[chainID] = &registeredChain{name: name}
+
+	if _, ok := r.treeSizeLocked(); !ok {
+		delete(r.chains, chainID)
+		return ErrTooManyChains
+	}
+
+	return r.store.Save(r.chainNamesLocked())
+}
+
+// Chains returns every currently registered chain, including its
+// last-submitted candidate block hash, if any.
+func (r *AuxChainRegistry) Chains() []ChainInfo {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	infos := make([]ChainInfo, 0, len(r.chains))
+	for id, chain := range r.chains {
+		infos = append(infos, ChainInfo{ChainID: id, Name: chain.name, Candidate: chain.candidate})
+	}
+	return infos
+}
+
+// SubmitCandidate records hash as chainID's current candidate block, to be
+// folded in as a leaf the next time BuildAuxRoot or BranchFor is called.
+func (r *AuxChainRegistry) SubmitCandidate(chainID int32, hash chainhash.Hash) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	chain, ok := r.chains[chainID]
+	if !ok {
+		return ErrChainNotRegistered
+	}
+	chain.candidate = hash
+	return nil
+}
+
+// BuildAuxRoot computes the merged-mining merkle root over every
+// registered chain's current candidate block, padding unused leaves with
+// the zero hash.
+func (r *AuxChainRegistry) BuildAuxRoot() (AuxRoot, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if len(r.chains) == 0 {
+		return AuxRoot{}, ErrNoRegisteredChains
+	}
+
+	size, ok := r.treeSizeLocked()
+	if !ok {
+		return AuxRoot{}, ErrTooManyChains
+	}
+	leaves := r.leavesLocked(int32(1) << size)
+
+	return AuxRoot{Root: merkleRoot(leaves), MerkleSize: size}, nil
+}
+
+// BranchFor returns the merkle branch that proves chainID's slot in the
+// tree BuildAuxRoot would currently compute, along with the tree's size
+// exponent.
+func (r *AuxChainRegistry) BranchFor(chainID int32) (wire.MerkleBranch, uint32, error) {
+	r.mtx.RLock()
+	defer r.mtx.RUnlock()
+
+	if _, ok := r.chains[chainID]; !ok {
+		return wire.MerkleBranch{}, 0, ErrChainNotRegistered
+	}
+
+	size, ok := r.treeSizeLocked()
+	if !ok {
+		return wire.MerkleBranch{}, 0, ErrTooManyChains
+	}
+	count := int32(1) << size
+	level := r.leavesLocked(count)
+	index := slotFor(chainID, count)
+
+	var branch wire.MerkleBranch
+	for len(level) > 1 {
+		sibling := level[index^1]
+		branch.LinkHashes = append(branch.LinkHashes, &sibling)
+		if index%2 == 1 {
+			branch.BranchSidesBitmask |= 1 << uint(len(branch.LinkHashes)-1)
+		}
+		level = foldLevel(level)
+		index /= 2
+	}
+
+	return branch, size, nil
+}
+
+// VerifyCandidate checks that auxHeader proves chainID's current candidate
+// was merge-mined under auxHeader.ParentBlock. The branch is always
+// replaced with the one this registry's own tree implies for chainID,
+// since honoring a self-reported branch would let a chain claim a slot
+// that isn't its own.
+func (r *AuxChainRegistry) VerifyCandidate(auxHeader *wire.AuxBlockHeader, chainID int32) error {
+	branch, _, err := r.BranchFor(chainID)
+	if err != nil {
+		return err
+	}
+
+	r.mtx.RLock()
+	candidate := r.chains[chainID].candidate
+	r.mtx.RUnlock()
+
+	auxHeader.BlockchainBranch = branch
+	return doge.VerifyAuxPoW(auxHeader, chainID, candidate)
+}
+
+// chainNamesLocked snapshots the registry's chain ID -> name mapping for
+// persistence. r.mtx must be held.
+func (r *AuxChainRegistry) chainNamesLocked() map[int32]string {
+	names := make(map[int32]string, len(r.chains))
+	for id, chain := range r.chains {
+		names[id] = chain.name
+	}
+	return names
+}
+
+// treeSizeLocked returns the smallest tree-size exponent whose leaf count
+// both fits every registered chain and gives each one a distinct slot, up
+// to doge.MaxAuxPowMerkleSize. It reports false if no size in that range
+// works, which doge.VerifyAuxPoW would reject as ErrAuxPowMerkleSize
+// regardless of how the tree was built. r.mtx must be held.
+func (r *AuxChainRegistry) treeSizeLocked() (uint32, bool) {
+	for size := uint32(0); size <= doge.MaxAuxPowMerkleSize; size++ {
+		count := int32(1) << size
+		if int(count) < len(r.chains) {
+			continue
+		}
+		if slotsUniqueLocked(r.chains, count) {
+			return size, true
+		}
+	}
+	return 0, false
+}
+
+// leavesLocked builds the count-leaf array for the current candidates,
+// leaving any unused slot as the zero hash. r.mtx must be held.
+func (r *AuxChainRegistry) leavesLocked(count int32) []chainhash.Hash {
+	leaves := make([]chainhash.Hash, count)
+	for id, chain := range r.chains {
+		leaves[slotFor(id, count)] = chain.candidate
+	}
+	return leaves
+}
+
+// slotsUniqueLocked reports whether every chain in chains maps to a
+// distinct slot out of count.
+func slotsUniqueLocked(chains map[int32]*registeredChain, count int32) bool {
+	seen := make(map[int32]bool, len(chains))
+	for id := range chains {
+		slot := slotFor(id, count)
+		if seen[slot] {
+			return false
+		}
+		seen[slot] = true
+	}
+	return true
+}
+
+// slotFor returns chainID's slot in a tree of count leaves: chainID mod
+// count, kept non-negative. For non-negative chain IDs this matches
+// doge.VerifyAuxPoW's expected-index rule for a shared rand of zero;
+// doge.VerifyAuxPoW does not normalise a negative chain ID this way.
+func slotFor(chainID, count int32) int32 {
+	slot := chainID % count
+	if slot < 0 {
+		slot += count
+	}
+	return slot
+}
+
+// merkleRoot folds leaves pairwise with double-SHA256 until a single root
+// remains. len(leaves) must be a power of two.
+func merkleRoot(leaves []chainhash.Hash) chainhash.Hash {
+	level := leaves
+	for len(level) > 1 {
+		level = foldLevel(level)
+	}
+	return level[0]
+}
+
+// foldLevel combines adjacent pairs in level into the next level up.
+func foldLevel(level []chainhash.Hash) []chainhash.Hash {
+	next := make([]chainhash.Hash, len(level)/2)
+	for i := range next {
+		var combined [chainhash.HashSize * 2]byte
+		copy(combined[:chainhash.HashSize], level[2*i][:])
+		copy(combined[chainhash.HashSize:], level[2*i+1][:])
+		next[i] = chainhash.DoubleHashH(combined[:])
+	}
+	return next
+}
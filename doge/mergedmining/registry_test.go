@@ -0,0 +1,148 @@
+package mergedmining
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// memStore is an in-memory Store used to test AuxChainRegistry without
+// touching disk.
+type memStore struct {
+	saved map[int32]string
+}
+
+func (s *memStore) Load() (map[int32]string, error) {
+	if s.saved == nil {
+		return map[int32]string{}, nil
+	}
+	return s.saved, nil
+}
+
+func (s *memStore) Save(chains map[int32]string) error {
+	s.saved = chains
+	return nil
+}
+
+func newTestRegistry(t *testing.T) *AuxChainRegistry {
+	t.Helper()
+	r, err := NewAuxChainRegistry(&memStore{})
+	if err != nil {
+		t.Fatalf("NewAuxChainRegistry() error = %v", err)
+	}
+	return r
+}
+
+func TestRegisterChain(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if err := r.RegisterChain(1, "namecoin"); err != nil {
+		t.Fatalf("RegisterChain() error = %v", err)
+	}
+	if err := r.RegisterChain(1, "namecoin"); err != ErrChainAlreadyRegistered {
+		t.Fatalf("RegisterChain() duplicate err = %v, want %v", err, ErrChainAlreadyRegistered)
+	}
+}
+
+func TestBuildAuxRootNoChains(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if _, err := r.BuildAuxRoot(); err != ErrNoRegisteredChains {
+		t.Fatalf("BuildAuxRoot() err = %v, want %v", err, ErrNoRegisteredChains)
+	}
+}
+
+func TestBranchForReproducesRoot(t *testing.T) {
+	r := newTestRegistry(t)
+
+	chainIDs := []int32{0, 1, 2, 5}
+	for i, id := range chainIDs {
+		if err := r.RegisterChain(id, "chain"); err != nil {
+			t.Fatalf("RegisterChain(%d) error = %v", id, err)
+		}
+		candidate := chainhash.Hash{byte(i + 1)}
+		if err := r.SubmitCandidate(id, candidate); err != nil {
+			t.Fatalf("SubmitCandidate(%d) error = %v", id, err)
+		}
+	}
+
+	root, err := r.BuildAuxRoot()
+	if err != nil {
+		t.Fatalf("BuildAuxRoot() error = %v", err)
+	}
+
+	for _, id := range chainIDs {
+		branch, size, err := r.BranchFor(id)
+		if err != nil {
+			t.Fatalf("BranchFor(%d) error = %v", id, err)
+		}
+		if size != root.MerkleSize {
+			t.Fatalf("BranchFor(%d) size = %d, want %d", id, size, root.MerkleSize)
+		}
+
+		leaf := r.Chains()
+		var candidate chainhash.Hash
+		for _, info := range leaf {
+			if info.ChainID == id {
+				candidate = info.Candidate
+			}
+		}
+
+		got := walkBranch(candidate, branch)
+		if got != root.Root {
+			t.Fatalf("branch for chain %d does not reproduce the root", id)
+		}
+	}
+}
+
+func TestRegisterChainRejectsTreeLargerThanMax(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if err := r.RegisterChain(0, "primary-aux"); err != nil {
+		t.Fatalf("RegisterChain() error = %v", err)
+	}
+
+	// 1<<30 collides with chain 0 in every tree up to and including
+	// doge.MaxAuxPowMerkleSize, since it's an exact multiple of every
+	// smaller power of two -- no tree size in range can give both chains
+	// a distinct slot.
+	const collidingChainID = 1 << 30
+	if err := r.RegisterChain(collidingChainID, "colliding-aux"); err != ErrTooManyChains {
+		t.Fatalf("RegisterChain() err = %v, want %v", err, ErrTooManyChains)
+	}
+
+	// The rejected registration must not have stuck around.
+	for _, info := range r.Chains() {
+		if info.ChainID == collidingChainID {
+			t.Fatalf("RegisterChain() left a rejected chain %d registered", collidingChainID)
+		}
+	}
+}
+
+func TestVerifyCandidateUnknownChain(t *testing.T) {
+	r := newTestRegistry(t)
+
+	err := r.VerifyCandidate(&wire.AuxBlockHeader{}, 42)
+	if err != ErrChainNotRegistered {
+		t.Fatalf("VerifyCandidate() err = %v, want %v", err, ErrChainNotRegistered)
+	}
+}
+
+// walkBranch re-derives a merkle root the same way doge.VerifyAuxPoW does,
+// so tests can check BranchFor's output without depending on doge's
+// unexported helper.
+func walkBranch(hash chainhash.Hash, branch wire.MerkleBranch) chainhash.Hash {
+	for i, link := range branch.LinkHashes {
+		var combined [chainhash.HashSize * 2]byte
+		if (branch.BranchSidesBitmask>>uint(i))&1 == 1 {
+			copy(combined[:chainhash.HashSize], link[:])
+			copy(combined[chainhash.HashSize:], hash[:])
+		} else {
+			copy(combined[:chainhash.HashSize], hash[:])
+			copy(combined[chainhash.HashSize:], link[:])
+		}
+		hash = chainhash.DoubleHashH(combined[:])
+	}
+	return hash
+}
@@ -0,0 +1,78 @@
+package mergedmining
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// RegisterAuxChainResult is the reply to the registerauxchain RPC.
+type RegisterAuxChainResult struct {
+	ChainID int32  `json:"chainid"`
+	Name    string `json:"name"`
+}
+
+// ListAuxChainsResult is one entry of the listauxchains RPC's reply.
+type ListAuxChainsResult struct {
+	ChainID            int32  `json:"chainid"`
+	Name               string `json:"name"`
+	CandidateBlockHash string `json:"candidateblockhash,omitempty"`
+}
+
+// RegisterAuxChain implements the registerauxchain RPC: it registers a new
+// auxiliary chain under chainID so its candidate blocks are folded into
+// this node's merged-mining root.
+func (r *AuxChainRegistry) RegisterAuxChain(chainID int32, name string) (*RegisterAuxChainResult, error) {
+	if err := r.RegisterChain(chainID, name); err != nil {
+		return nil, err
+	}
+	return &RegisterAuxChainResult{ChainID: chainID, Name: name}, nil
+}
+
+// SubmitAuxCandidateResult is the reply to the submitauxcandidate RPC.
+type SubmitAuxCandidateResult struct {
+	ChainID   int32  `json:"chainid"`
+	Candidate string `json:"candidate"`
+}
+
+// SubmitAuxCandidate implements the submitauxcandidate RPC: it is the
+// entry point an auxiliary chain's own node calls to report the hash of
+// the block it wants merge-mined next, to be folded in as a leaf the next
+// time this node builds its merged-mining root. Nothing else in this
+// package is allowed to register a candidate on a chain's behalf.
+func (r *AuxChainRegistry) SubmitAuxCandidate(chainID int32, blockHash chainhash.Hash) (*SubmitAuxCandidateResult, error) {
+	if err := r.SubmitCandidate(chainID, blockHash); err != nil {
+		return nil, err
+	}
+	return &SubmitAuxCandidateResult{ChainID: chainID, Candidate: blockHash.String()}, nil
+}
+
+// VerifyAuxCandidateResult is the reply to the verifyauxcandidate RPC.
+type VerifyAuxCandidateResult struct {
+	ChainID int32 `json:"chainid"`
+	Valid   bool  `json:"valid"`
+}
+
+// VerifyAuxCandidate implements the verifyauxcandidate RPC: it checks
+// whether auxHeader proves chainID's currently registered candidate was
+// merge-mined under this node's chain, without submitting or accepting
+// anything -- the auxiliary chain itself is responsible for submitting
+// the proof to its own chain.
+func (r *AuxChainRegistry) VerifyAuxCandidate(auxHeader *wire.AuxBlockHeader, chainID int32) *VerifyAuxCandidateResult {
+	return &VerifyAuxCandidateResult{ChainID: chainID, Valid: r.VerifyCandidate(auxHeader, chainID) == nil}
+}
+
+// ListAuxChains implements the listauxchains RPC: it reports every
+// registered auxiliary chain and its current candidate block, if any.
+func (r *AuxChainRegistry) ListAuxChains() []ListAuxChainsResult {
+	chains := r.Chains()
+
+	results := make([]ListAuxChainsResult, len(chains))
+	for i, chain := range chains {
+		result := ListAuxChainsResult{ChainID: chain.ChainID, Name: chain.Name}
+		if chain.Candidate != (chainhash.Hash{}) {
+			result.CandidateBlockHash = chain.Candidate.String()
+		}
+		results[i] = result
+	}
+	return results
+}
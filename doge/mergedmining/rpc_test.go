@@ -0,0 +1,47 @@
+package mergedmining
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSubmitAuxCandidate(t *testing.T) {
+	r := newTestRegistry(t)
+	if err := r.RegisterChain(1, "namecoin"); err != nil {
+		t.Fatalf("RegisterChain() error = %v", err)
+	}
+
+	hash := chainhash.Hash{0x42}
+	result, err := r.SubmitAuxCandidate(1, hash)
+	if err != nil {
+		t.Fatalf("SubmitAuxCandidate() error = %v", err)
+	}
+	if result.ChainID != 1 || result.Candidate != hash.String() {
+		t.Fatalf("SubmitAuxCandidate() result = %+v", result)
+	}
+
+	for _, chain := range r.Chains() {
+		if chain.ChainID == 1 && chain.Candidate != hash {
+			t.Fatalf("SubmitAuxCandidate() did not record the candidate on the registry")
+		}
+	}
+}
+
+func TestSubmitAuxCandidateUnknownChain(t *testing.T) {
+	r := newTestRegistry(t)
+
+	if _, err := r.SubmitAuxCandidate(1, chainhash.Hash{}); err != ErrChainNotRegistered {
+		t.Fatalf("SubmitAuxCandidate() err = %v, want %v", err, ErrChainNotRegistered)
+	}
+}
+
+func TestVerifyAuxCandidateUnknownChain(t *testing.T) {
+	r := newTestRegistry(t)
+
+	result := r.VerifyAuxCandidate(&wire.AuxBlockHeader{}, 1)
+	if result.Valid {
+		t.Fatalf("VerifyAuxCandidate() valid = true for an unregistered chain")
+	}
+}
@@ -0,0 +1,58 @@
+package mergedmining
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+)
+
+// Store persists the set of registered auxiliary chain IDs and names so an
+// AuxChainRegistry survives restarts. Candidate block hashes are
+// intentionally not persisted, since they are only meaningful for the
+// lifetime of the chain tip that produced them.
+type Store interface {
+	// Load returns the previously saved chain ID -> name mapping, or an
+	// empty map if nothing has been saved yet.
+	Load() (map[int32]string, error)
+
+	// Save persists the given chain ID -> name mapping, replacing
+	// whatever was previously saved.
+	Save(chains map[int32]string) error
+}
+
+// FileStore is a Store backed by a single JSON file on disk.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a FileStore that reads and writes its chain set at
+// path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load implements Store.
+func (s *FileStore) Load() (map[int32]string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[int32]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	chains := make(map[int32]string)
+	if err := json.Unmarshal(data, &chains); err != nil {
+		return nil, err
+	}
+	return chains, nil
+}
+
+// Save implements Store.
+func (s *FileStore) Save(chains map[int32]string) error {
+	data, err := json.MarshalIndent(chains, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0644)
+}
@@ -0,0 +1,265 @@
+// Package mining implements the merged-mining RPC surface
+// (createauxblock/submitauxblock) that lets an external Dogecoin or
+// Namecoin pool point at btcd without embedding its own coinbase
+// construction.
+package mining
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/doge"
+	"github.com/btcsuite/btcd/doge/mergedmining"
+	"github.com/btcsuite/btcd/mining"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// mergedMiningNonceSize and mergedMiningTreeSize are the widths of the two
+// fields that follow the committed root: a nonce used to pick this
+// chain's slot, and the tree size exponent. Reserving them here, even
+// though AuxTemplateCache only ever tracks a single chain, keeps the
+// coinbase layout identical to doge/mergedmining's multi-chain tree.
+const (
+	mergedMiningNonceSize = 4
+	mergedMiningTreeSize  = 4
+)
+
+// ErrNoCachedTemplate is returned by SubmitAuxBlock when hashHex does not
+// match any template created by a prior createauxblock call, most likely
+// because the tip has since moved on and the template expired.
+var ErrNoCachedTemplate = errors.New("doge/mining: no cached aux block template for that hash")
+
+// CreateAuxBlockResult is the reply to the createauxblock RPC.
+type CreateAuxBlockResult struct {
+	Hash              string `json:"hash"`
+	ChainID           int32  `json:"chainid"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	CoinbaseValue     int64  `json:"coinbasevalue"`
+	Bits              string `json:"bits"`
+	Height            int32  `json:"height"`
+	Target            string `json:"target"`
+}
+
+// auxBlockTemplate pairs a mining.BlockTemplate with the merged-mining
+// root that was committed into its own coinbase when it was created.
+type auxBlockTemplate struct {
+	template *mining.BlockTemplate
+	auxRoot  chainhash.Hash
+}
+
+// AuxTemplateCache tracks in-flight merged-mining templates by the hash of
+// the candidate block they represent, the same way getblocktemplate's
+// long-poll logic tracks its own template by the current tip. A hash stays
+// valid until the tip it was built on changes, at which point
+// createauxblock starts handing out new hashes and old ones simply stop
+// being found by SubmitAuxBlock.
+type AuxTemplateCache struct {
+	mtx       sync.Mutex
+	templates map[chainhash.Hash]*auxBlockTemplate
+	tip       chainhash.Hash
+}
+
+// NewAuxTemplateCache returns an empty AuxTemplateCache.
+func NewAuxTemplateCache() *AuxTemplateCache {
+	return &AuxTemplateCache{templates: make(map[chainhash.Hash]*auxBlockTemplate)}
+}
+
+// CreateAuxBlock builds a new candidate block paying to payoutAddress via
+// generator, commits a merged-mining root for auxChainID into its
+// coinbase, caches the template under the resulting block hash, and
+// returns the fields a merged-mining pool needs to hand out work.
+//
+// If the chain tip has moved since the last call, the cache is reset so
+// stale templates can't be submitted against a tip they no longer build
+// on -- the same "hash changes when tip changes" semantics
+// getblocktemplate's long poll already relies on.
+func (c *AuxTemplateCache) CreateAuxBlock(generator *mining.BlkTmplGenerator, payoutAddress btcutil.Address, auxChainID int32) (*CreateAuxBlockResult, error) {
+	payoutScript, err := txscript.PayToAddrScript(payoutAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := generator.NewBlockTemplate(payoutScript)
+	if err != nil {
+		return nil, err
+	}
+	header := &template.Block.Header
+
+	// With only one chain in play, the merged-mining tree is a single
+	// leaf. The root embedded here is this candidate's hash from before the
+	// embedding; embedding it changes the block hash, and that final hash
+	// is the one handed out below.
+	auxRoot := header.BlockHash()
+	hash, err := commitAuxRoot(template, auxRoot, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mtx.Lock()
+	if header.PrevBlock != c.tip {
+		c.templates = make(map[chainhash.Hash]*auxBlockTemplate)
+		c.tip = header.PrevBlock
+	}
+	c.templates[hash] = &auxBlockTemplate{template: template, auxRoot: auxRoot}
+	c.mtx.Unlock()
+
+	target := blockchain.CompactToBig(header.Bits)
+
+	return &CreateAuxBlockResult{
+		Hash:              hash.String(),
+		ChainID:           auxChainID,
+		PreviousBlockHash: header.PrevBlock.String(),
+		CoinbaseValue:     template.Block.Transactions[0].TxOut[0].Value,
+		Bits:              fmt.Sprintf("%08x", header.Bits),
+		Height:            int32(template.Height),
+		Target:            fmt.Sprintf("%064x", target),
+	}, nil
+}
+
+// CreatePrimaryBlockResult is the reply when this node builds a candidate
+// block that acts as the primary side of a merged-mining setup for several
+// auxiliary chains at once.
+type CreatePrimaryBlockResult struct {
+	Hash              string `json:"hash"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	CoinbaseValue     int64  `json:"coinbasevalue"`
+	Bits              string `json:"bits"`
+	Height            int32  `json:"height"`
+	Target            string `json:"target"`
+	MerkleSize        uint32 `json:"merklesize"`
+}
+
+// CreatePrimaryBlock builds a new candidate block paying to payoutAddress
+// via generator and commits a root covering every chain currently
+// registered in registry into its coinbase, letting this node act as the
+// primary side of a merged-mining setup for several auxiliary chains at
+// once.
+//
+// Each registered chain submits its own candidate out of band, via
+// registry's submitauxcandidate RPC, before calling this; CreatePrimaryBlock
+// only ever builds a root from what's already registered, it never
+// registers a candidate on a chain's behalf. Once this block's proof of
+// work is found, it is solved and submitted through the node's normal
+// block-acceptance path like any other block -- it carries this chain's
+// own real proof of work, not an AuxPoW, so AuxTemplateCache has no part
+// in it. An auxiliary chain fetches its own merkle branch for the
+// resulting root with registry.BranchFor, to build the AuxPoW header it
+// submits to its own chain.
+func CreatePrimaryBlock(generator *mining.BlkTmplGenerator, payoutAddress btcutil.Address, registry *mergedmining.AuxChainRegistry) (*CreatePrimaryBlockResult, error) {
+	payoutScript, err := txscript.PayToAddrScript(payoutAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := generator.NewBlockTemplate(payoutScript)
+	if err != nil {
+		return nil, err
+	}
+	header := &template.Block.Header
+
+	auxRoot, err := registry.BuildAuxRoot()
+	if err != nil {
+		return nil, err
+	}
+	hash, err := commitAuxRoot(template, auxRoot.Root, auxRoot.MerkleSize)
+	if err != nil {
+		return nil, err
+	}
+
+	target := blockchain.CompactToBig(header.Bits)
+
+	return &CreatePrimaryBlockResult{
+		Hash:              hash.String(),
+		PreviousBlockHash: header.PrevBlock.String(),
+		CoinbaseValue:     template.Block.Transactions[0].TxOut[0].Value,
+		Bits:              fmt.Sprintf("%08x", header.Bits),
+		Height:            int32(template.Height),
+		Target:            fmt.Sprintf("%064x", target),
+		MerkleSize:        auxRoot.MerkleSize,
+	}, nil
+}
+
+// SubmitAuxBlock looks up the template cached under hashHex, decodes
+// auxPowHex into a wire.AuxBlockHeader, verifies it against hashHex, the
+// block hash the template was cached under and handed out as (the root of
+// a single-chain merged-mining tree is that hash), attaches it to the
+// template's coinbase block, and hands the finished block to submit for
+// processing through the normal block-acceptance path.
+func (c *AuxTemplateCache) SubmitAuxBlock(hashHex, auxPowHex string, auxChainID int32, submit func(*wire.MsgBlock) error) error {
+	hash, err := chainhash.NewHashFromStr(hashHex)
+	if err != nil {
+		return err
+	}
+
+	c.mtx.Lock()
+	entry, ok := c.templates[*hash]
+	if ok {
+		delete(c.templates, *hash)
+	}
+	c.mtx.Unlock()
+	if !ok {
+		return ErrNoCachedTemplate
+	}
+
+	rawAuxPow, err := hex.DecodeString(auxPowHex)
+	if err != nil {
+		return err
+	}
+
+	var auxHeader wire.AuxBlockHeader
+	if err := auxHeader.Deserialize(bytes.NewReader(rawAuxPow)); err != nil {
+		return err
+	}
+
+	if err := doge.VerifyAuxPoW(&auxHeader, auxChainID, *hash); err != nil {
+		return err
+	}
+
+	entry.template.Block.Header.SetAuxPoW(&auxHeader)
+	return submit(entry.template.Block)
+}
+
+// commitAuxRoot embeds root and merkleSize into template's coinbase and
+// rebuilds the block's merkle tree to match, since changing the coinbase
+// changes the merkle root -- and therefore the block hash -- that every
+// other leaf's commitment depends on. It returns the resulting block hash.
+func commitAuxRoot(template *mining.BlockTemplate, root chainhash.Hash, merkleSize uint32) (chainhash.Hash, error) {
+	if err := embedAuxCommitment(template.Block.Transactions[0], root, merkleSize); err != nil {
+		return chainhash.Hash{}, err
+	}
+
+	merkles := blockchain.BuildMerkleTreeStore(btcutil.NewBlock(template.Block).Transactions(), false)
+	template.Block.Header.MerkleRoot = *merkles[len(merkles)-1]
+
+	return template.Block.Header.BlockHash(), nil
+}
+
+// embedAuxCommitment appends the merged-mining magic, root, a reserved
+// nonce field and the tree-size exponent to coinbase's first input's
+// signature script.
+func embedAuxCommitment(coinbase *wire.MsgTx, root chainhash.Hash, merkleSize uint32) error {
+	if len(coinbase.TxIn) == 0 {
+		return errors.New("doge/mining: coinbase has no inputs to commit the aux root to")
+	}
+
+	commitment := make([]byte, 0, len(doge.AuxPowMagic)+chainhash.HashSize+mergedMiningNonceSize+mergedMiningTreeSize)
+	commitment = append(commitment, doge.AuxPowMagic...)
+	commitment = append(commitment, root[:]...)
+
+	var tmp [4]byte
+	binary.LittleEndian.PutUint32(tmp[:], 0) // rand is fixed at zero; slot = chainID mod tree size
+	commitment = append(commitment, tmp[:]...)
+	binary.LittleEndian.PutUint32(tmp[:], merkleSize)
+	commitment = append(commitment, tmp[:]...)
+
+	coinbase.TxIn[0].SignatureScript = append(coinbase.TxIn[0].SignatureScript, commitment...)
+	return nil
+}
@@ -0,0 +1,77 @@
+package mining
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/doge"
+	"github.com/btcsuite/btcd/mining"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestEmbedAuxCommitment(t *testing.T) {
+	root := chainhash.Hash{0x42}
+	coinbase := &wire.MsgTx{
+		TxIn: []*wire.TxIn{{SignatureScript: []byte{0x01}}},
+	}
+
+	if err := embedAuxCommitment(coinbase, root, 0); err != nil {
+		t.Fatalf("embedAuxCommitment() error = %v", err)
+	}
+
+	script := coinbase.TxIn[0].SignatureScript
+	if !bytes.HasPrefix(script[1:], doge.AuxPowMagic) {
+		t.Fatalf("commitment does not start with the merged-mining magic")
+	}
+	rootStart := 1 + len(doge.AuxPowMagic)
+	if !bytes.Equal(script[rootStart:rootStart+chainhash.HashSize], root[:]) {
+		t.Fatalf("committed root does not match input root")
+	}
+}
+
+func TestEmbedAuxCommitmentNoInputs(t *testing.T) {
+	coinbase := &wire.MsgTx{}
+	if err := embedAuxCommitment(coinbase, chainhash.Hash{}, 0); err == nil {
+		t.Fatalf("expected an error for a coinbase with no inputs")
+	}
+}
+
+func TestCommitAuxRootRecomputesMerkleRoot(t *testing.T) {
+	coinbase := &wire.MsgTx{
+		TxIn:  []*wire.TxIn{{SignatureScript: []byte{0x01}}},
+		TxOut: []*wire.TxOut{{Value: 5000000000}},
+	}
+	block := &wire.MsgBlock{Transactions: []*wire.MsgTx{coinbase}}
+	template := &mining.BlockTemplate{Block: block}
+
+	staleRoot := block.Header.MerkleRoot
+	staleHash := block.Header.BlockHash()
+
+	hash, err := commitAuxRoot(template, chainhash.Hash{0x11}, 0)
+	if err != nil {
+		t.Fatalf("commitAuxRoot() error = %v", err)
+	}
+
+	if block.Header.MerkleRoot == staleRoot {
+		t.Fatalf("commitAuxRoot() did not recompute the merkle root after the coinbase changed")
+	}
+	if hash == staleHash {
+		t.Fatalf("commitAuxRoot() returned the pre-commitment hash")
+	}
+	if hash != block.Header.BlockHash() {
+		t.Fatalf("commitAuxRoot() hash does not match the final header hash")
+	}
+}
+
+func TestSubmitAuxBlockNoCachedTemplate(t *testing.T) {
+	cache := NewAuxTemplateCache()
+
+	err := cache.SubmitAuxBlock(chainhash.Hash{}.String(), "00", 0, func(*wire.MsgBlock) error {
+		t.Fatalf("submit should not be called when there is no cached template")
+		return nil
+	})
+	if err != ErrNoCachedTemplate {
+		t.Fatalf("SubmitAuxBlock() err = %v, want %v", err, ErrNoCachedTemplate)
+	}
+}
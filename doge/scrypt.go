@@ -0,0 +1,121 @@
+package doge
+
+import (
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/container/lru"
+	"github.com/btcsuite/btcd/wire"
+	"golang.org/x/crypto/scrypt"
+)
+
+// scryptPoWCacheLimit bounds the number of parent-header Scrypt digests we
+// keep around. Scrypt is expensive by design, and the same parent header
+// is frequently re-hashed as it crosses the inv/getdata/getheaders paths
+// for relayed blocks, so a small cache saves real CPU.
+const scryptPoWCacheLimit = 200
+
+// scryptPoWCache maps a parent header's double-SHA256 hash (cheap to
+// compute) to its Scrypt digest (expensive to compute).
+var scryptPoWCache = lru.NewCache[chainhash.Hash, [32]byte](scryptPoWCacheLimit)
+
+// ErrScryptPoWTooHigh is returned when a header's Scrypt proof-of-work
+// digest is numerically higher than the target implied by its bits.
+var ErrScryptPoWTooHigh = errors.New("doge: scrypt proof of work hash is higher than target difficulty")
+
+// ErrScryptPoWTargetTooEasy is returned when bits decodes to a target
+// easier than the network's proof-of-work limit.
+var ErrScryptPoWTargetTooEasy = errors.New("doge: scrypt proof of work target exceeds the proof-of-work limit")
+
+// ErrAuxPoWMissingAuxData is returned when a header's version signals
+// merged mining but it carries no AuxData commitment to check.
+var ErrAuxPoWMissingAuxData = errors.New("doge: auxpow block header is missing its AuxData commitment")
+
+// serializeParentHeader encodes the 80-byte canonical Dogecoin/Litecoin
+// block header fields, in the same layout used for hashing on the wire.
+func serializeParentHeader(pb *wire.ParentBlock) []byte {
+	buf := make([]byte, 80)
+	binary.LittleEndian.PutUint32(buf[0:4], uint32(pb.Version))
+	copy(buf[4:36], pb.PrevBlock[:])
+	copy(buf[36:68], pb.MerkleRoot[:])
+	binary.LittleEndian.PutUint32(buf[68:72], uint32(pb.Timestamp.Unix()))
+	binary.LittleEndian.PutUint32(buf[72:76], pb.Bits)
+	binary.LittleEndian.PutUint32(buf[76:80], pb.Nonce)
+	return buf
+}
+
+// scryptDigest returns the 32-byte Scrypt(N=1024, r=1, p=1) digest of pb's
+// serialized header, consulting and populating scryptPoWCache.
+func scryptDigest(pb *wire.ParentBlock) (chainhash.Hash, [32]byte, error) {
+	raw := serializeParentHeader(pb)
+	key := chainhash.DoubleHashH(raw)
+
+	if digest, ok := scryptPoWCache.Get(key); ok {
+		return key, digest, nil
+	}
+
+	sum, err := scrypt.Key(raw, raw, 1024, 1, 1, 32)
+	if err != nil {
+		return key, [32]byte{}, err
+	}
+
+	var digest [32]byte
+	copy(digest[:], sum)
+	scryptPoWCache.Add(key, digest)
+	return key, digest, nil
+}
+
+// CheckProofOfWork verifies that pb's Scrypt proof of work satisfies the
+// difficulty target encoded in bits, and that bits itself is not looser
+// than powLimit.
+func CheckProofOfWork(pb *wire.ParentBlock, bits uint32, powLimit *big.Int) error {
+	target := blockchain.CompactToBig(bits)
+	if target.Cmp(powLimit) > 0 {
+		return ErrScryptPoWTargetTooEasy
+	}
+
+	_, digest, err := scryptDigest(pb)
+	if err != nil {
+		return err
+	}
+
+	// The digest is produced little-endian; reverse it to interpret as a
+	// big-endian integer for comparison against the target.
+	reversed := make([]byte, len(digest))
+	for i, b := range digest {
+		reversed[len(digest)-1-i] = b
+	}
+	hashNum := new(big.Int).SetBytes(reversed)
+	if hashNum.Cmp(target) > 0 {
+		return ErrScryptPoWTooHigh
+	}
+
+	return nil
+}
+
+// CheckBlockProofOfWork checks the Scrypt proof of work for header,
+// following into its AuxPoW parent block when header signals merged
+// mining, and hashing header itself otherwise. It is the single dispatch
+// point ValidateBlockHeader (and in turn block acceptance) calls instead of
+// hashing header's version directly, so that AuxPoW and non-AuxPoW headers
+// are never checked against the wrong bytes.
+func CheckBlockProofOfWork(header *wire.BlockHeader, bits uint32, powLimit *big.Int) error {
+	if IsAuxPoWBlockVersion(header.Version) {
+		if header.AuxData == nil {
+			return ErrAuxPoWMissingAuxData
+		}
+		return CheckProofOfWork(&header.AuxData.ParentBlock, bits, powLimit)
+	}
+
+	return CheckProofOfWork(&wire.ParentBlock{
+		Version:    header.Version,
+		PrevBlock:  header.PrevBlock,
+		MerkleRoot: header.MerkleRoot,
+		Timestamp:  header.Timestamp,
+		Bits:       header.Bits,
+		Nonce:      header.Nonce,
+	}, bits, powLimit)
+}
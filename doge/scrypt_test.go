@@ -0,0 +1,116 @@
+package doge
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestCheckProofOfWork(t *testing.T) {
+	// Nonce 3362412 gives this header a scrypt digest below the 0x1e0ffff0
+	// target.
+	pb := &wire.ParentBlock{
+		Version:   0x00000002,
+		Timestamp: time.Unix(1500000000, 0),
+		Bits:      0x1e0ffff0,
+		Nonce:     3362412,
+	}
+
+	tests := []struct {
+		name     string
+		bits     uint32
+		powLimit *big.Int
+		wantErr  error
+	}{
+		{
+			name:     "bits looser than powLimit is rejected",
+			bits:     0x1e0ffff0,
+			powLimit: blockchain.CompactToBig(0x1d00ffff),
+			wantErr:  ErrScryptPoWTargetTooEasy,
+		},
+		{
+			name:     "header meeting the powLimit target is accepted",
+			bits:     0x1e0ffff0,
+			powLimit: blockchain.CompactToBig(0x1e0ffff0),
+			wantErr:  nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := CheckProofOfWork(pb, tc.bits, tc.powLimit)
+			if err != tc.wantErr {
+				t.Fatalf("CheckProofOfWork() err = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckBlockProofOfWork(t *testing.T) {
+	powLimit := blockchain.CompactToBig(0x1e0ffff0)
+
+	t.Run("non-auxpow header hashes itself", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version:   0x00000002,
+			Timestamp: time.Unix(1500000000, 0),
+			Bits:      0x1e0ffff0,
+			Nonce:     3362412,
+		}
+
+		if err := CheckBlockProofOfWork(header, 0x1e0ffff0, powLimit); err != nil {
+			t.Fatalf("CheckBlockProofOfWork() error = %v", err)
+		}
+	})
+
+	t.Run("auxpow header without AuxData is rejected", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version: dogeChainID<<16 | blockVersionFlagAuxpow,
+		}
+
+		err := CheckBlockProofOfWork(header, 0x1e0ffff0, powLimit)
+		if err != ErrAuxPoWMissingAuxData {
+			t.Fatalf("CheckBlockProofOfWork() err = %v, want %v", err, ErrAuxPoWMissingAuxData)
+		}
+	})
+
+	t.Run("auxpow header follows into its parent block", func(t *testing.T) {
+		header := &wire.BlockHeader{
+			Version: dogeChainID<<16 | blockVersionFlagAuxpow,
+			AuxData: &wire.AuxBlockHeader{
+				ParentBlock: wire.ParentBlock{
+					Version:   0x00000002,
+					Timestamp: time.Unix(1500000000, 0),
+					Bits:      0x1e0ffff0,
+					Nonce:     3362412,
+				},
+			},
+		}
+
+		if err := CheckBlockProofOfWork(header, 0x1e0ffff0, powLimit); err != nil {
+			t.Fatalf("CheckBlockProofOfWork() error = %v", err)
+		}
+	})
+}
+
+func TestScryptDigestCache(t *testing.T) {
+	pb := &wire.ParentBlock{Version: 2, Nonce: 1}
+
+	key1, digest1, err := scryptDigest(pb)
+	if err != nil {
+		t.Fatalf("scryptDigest() error = %v", err)
+	}
+	key2, digest2, err := scryptDigest(pb)
+	if err != nil {
+		t.Fatalf("scryptDigest() error = %v", err)
+	}
+
+	if key1 != key2 || digest1 != digest2 {
+		t.Fatalf("scryptDigest() not stable across calls for the same header")
+	}
+	if cached, ok := scryptPoWCache.Get(key1); !ok || cached != digest1 {
+		t.Fatalf("scryptDigest() did not store the digest in scryptPoWCache")
+	}
+}
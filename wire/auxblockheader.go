@@ -67,6 +67,10 @@ type MerkleBranch struct {
 	BranchSidesBitmask int32
 }
 
+// ToBlockHeader returns a BlockHeader with pb's fields as its pure header.
+// The parent block of an AuxPoW commitment is never itself AuxPoW (see
+// doge.VerifyAuxPoW's recursion check), so the result never carries
+// AuxData.
 func (pb *ParentBlock) ToBlockHeader() *BlockHeader {
 	return &BlockHeader{
 		Version:    pb.Version,
@@ -75,7 +79,6 @@ func (pb *ParentBlock) ToBlockHeader() *BlockHeader {
 		Timestamp:  pb.Timestamp,
 		Bits:       pb.Bits,
 		Nonce:      pb.Nonce,
-		AuxData:    AuxBlockHeader{},
 	}
 }
 
@@ -208,7 +211,7 @@ func writeAuxBlockHeader(w io.Writer, pver uint32, bh *AuxBlockHeader) error {
 	}
 	_ = writeElements(w, bh.BlockchainBranch.BranchSidesBitmask)
 
-	_ = writeBlockHeader(w, pver, bh.ParentBlock.ToBlockHeader(), true)
+	_ = writeBlockHeader(w, pver, bh.ParentBlock.ToBlockHeader())
 
 	return nil
 }
@@ -0,0 +1,236 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// MaxBlockHeaderPayload is the maximum number of bytes a pure block header
+// can be: 4 bytes version + chainhash.HashSize prev block + chainhash.HashSize
+// merkle root + 4 bytes timestamp + 4 bytes bits + 4 bytes nonce. AuxPoW
+// data, when present, is serialized separately and is not bounded by this
+// constant; see MaxAuxBlockHeaderPayload.
+const MaxBlockHeaderPayload = 16 + (chainhash.HashSize * 2)
+
+// blockVersionAuxPowFlag and minAuxPowBlockVersion identify Dogecoin/
+// Namecoin-style merged-mining block versions on the wire: the aux chain's
+// registered chain ID sits in the upper 16 bits of Version, and
+// blockVersionAuxPowFlag in the lower 16 bits signals that an AuxData
+// commitment follows the pure header on the wire.
+const (
+	blockVersionAuxPowFlag = 0x00000100
+	minAuxPowBlockVersion  = 0x00620002
+)
+
+// IsAuxPoWBlockVersion reports whether version signals that the header it
+// belongs to carries an AuxPoW commitment. BlockHeader's wire encoding
+// uses this to decide whether to read or write AuxData.
+func IsAuxPoWBlockVersion(version int32) bool {
+	return version >= minAuxPowBlockVersion && version&blockVersionAuxPowFlag != 0
+}
+
+// PureBlockHeader is the canonical 80-byte block header layout used for
+// hashing and proof-of-work: the subset of BlockHeader's fields that are
+// hashed and proven, common to every block regardless of whether it also
+// carries an AuxPoW commitment. See BlockHeader.ToPure.
+type PureBlockHeader struct {
+	// Version of the block.  This is not the same as the protocol version.
+	Version int32
+
+	// Hash of the previous block header in the block chain.
+	PrevBlock chainhash.Hash
+
+	// Merkle tree reference to hash of all transactions for the block.
+	MerkleRoot chainhash.Hash
+
+	// Time the block was created.  This is, unfortunately, encoded as a
+	// uint32 on the wire and therefore is limited to 2106.
+	Timestamp time.Time
+
+	// Difficulty target for the block.
+	Bits uint32
+
+	// Nonce used to generate the block.
+	Nonce uint32
+}
+
+// serialize writes the 80-byte pure header to w.
+func (h *PureBlockHeader) serialize(w io.Writer) error {
+	return writeElements(w, h.Version, &h.PrevBlock, &h.MerkleRoot,
+		uint32Time(h.Timestamp), h.Bits, h.Nonce)
+}
+
+// deserialize reads the 80-byte pure header from r.
+func (h *PureBlockHeader) deserialize(r io.Reader) error {
+	return readElements(r, &h.Version, &h.PrevBlock, &h.MerkleRoot,
+		(*uint32Time)(&h.Timestamp), &h.Bits, &h.Nonce)
+}
+
+// BlockHeader defines information about a block and is used in the bitcoin
+// block (MsgBlock) and headers (MsgHeaders) messages. Its fields stay flat,
+// matching every other wire message in this package, so existing keyed
+// composite literals keep compiling; use ToPure to get at just the hashed
+// and proven portion. AuxData carries this header's AuxPoW merged-mining
+// proof, and is only present -- and only read from or written to the wire
+// -- when IsAuxPoWBlockVersion(Version) is true.
+type BlockHeader struct {
+	// Version of the block.  This is not the same as the protocol version.
+	Version int32
+
+	// Hash of the previous block header in the block chain.
+	PrevBlock chainhash.Hash
+
+	// Merkle tree reference to hash of all transactions for the block.
+	MerkleRoot chainhash.Hash
+
+	// Time the block was created.  This is, unfortunately, encoded as a
+	// uint32 on the wire and therefore is limited to 2106.
+	Timestamp time.Time
+
+	// Difficulty target for the block.
+	Bits uint32
+
+	// Nonce used to generate the block.
+	Nonce uint32
+
+	// AuxData carries the AuxPoW merged-mining proof for this header, or
+	// nil if this header does not signal merged mining.
+	AuxData *AuxBlockHeader
+}
+
+// ToPure returns h's canonical 80-byte header fields -- the portion that is
+// actually hashed and proven -- discarding any AuxData commitment.
+func (h *BlockHeader) ToPure() PureBlockHeader {
+	return PureBlockHeader{
+		Version:    h.Version,
+		PrevBlock:  h.PrevBlock,
+		MerkleRoot: h.MerkleRoot,
+		Timestamp:  h.Timestamp,
+		Bits:       h.Bits,
+		Nonce:      h.Nonce,
+	}
+}
+
+// BlockHash computes the block identifier hash for the given block header.
+// Hashing always covers exactly the 80-byte pure header, regardless of
+// whether an AuxData commitment is present.
+func (h *BlockHeader) BlockHash() chainhash.Hash {
+	var buf bytes.Buffer
+	buf.Grow(MaxBlockHeaderPayload)
+
+	// Ignore the error returns since the only way the serialize can fail
+	// is if the writer is broken, and bytes.Buffer's Write never errors.
+	pure := h.ToPure()
+	_ = pure.serialize(&buf)
+	return chainhash.DoubleHashH(buf.Bytes())
+}
+
+// BtcDecode decodes r using the bitcoin protocol encoding into the
+// receiver. This is part of the Message interface implementation.
+// See Deserialize for decoding block headers stored to disk, such as in a
+// database, as opposed to decoding block headers from the wire.
+func (h *BlockHeader) BtcDecode(r io.Reader, pver uint32, enc MessageEncoding) error {
+	return readBlockHeader(r, pver, h)
+}
+
+// BtcEncode encodes the receiver to w using the bitcoin protocol encoding.
+// This is part of the Message interface implementation.
+// See Serialize for encoding block headers to be stored to disk, such as
+// in a database, as opposed to encoding block headers for the wire.
+func (h *BlockHeader) BtcEncode(w io.Writer, pver uint32, enc MessageEncoding) error {
+	return writeBlockHeader(w, pver, h)
+}
+
+// Deserialize decodes a block header from r into the receiver using a
+// format that is suitable for long-term storage such as a database while
+// respecting the Version field.
+func (h *BlockHeader) Deserialize(r io.Reader) error {
+	// At the current time, there is no difference between the wire
+	// encoding at protocol version 0 and the stable long-term storage
+	// format.  As a result, make use of readBlockHeader.
+	return readBlockHeader(r, 0, h)
+}
+
+// Serialize encodes a block header from r into the receiver using a format
+// that is suitable for long-term storage such as a database while
+// respecting the Version field.
+func (h *BlockHeader) Serialize(w io.Writer) error {
+	// At the current time, there is no difference between the wire
+	// encoding at protocol version 0 and the stable long-term storage
+	// format.  As a result, make use of writeBlockHeader.
+	return writeBlockHeader(w, 0, h)
+}
+
+// SetAuxPoW attaches aux as this header's AuxPoW commitment and sets the
+// version bit that marks the header as carrying one.
+func (h *BlockHeader) SetAuxPoW(aux *AuxBlockHeader) {
+	h.Version |= blockVersionAuxPowFlag
+	h.AuxData = aux
+}
+
+// NewBlockHeader returns a new BlockHeader using the provided version,
+// previous block hash, merkle root hash, difficulty bits, and nonce used
+// to generate the block with defaults for the remaining fields.
+func NewBlockHeader(version int32, prevHash, merkleRootHash *chainhash.Hash,
+	bits uint32, nonce uint32) *BlockHeader {
+
+	// Limit the timestamp to one second precision since the protocol
+	// doesn't support better.
+	return &BlockHeader{
+		Version:    version,
+		PrevBlock:  *prevHash,
+		MerkleRoot: *merkleRootHash,
+		Timestamp:  time.Unix(time.Now().Unix(), 0),
+		Bits:       bits,
+		Nonce:      nonce,
+	}
+}
+
+// readBlockHeader reads a bitcoin block header from r, following into its
+// AuxPoW commitment when bh.Version signals merged mining. See Deserialize
+// for decoding block headers stored to disk, such as in a database, as
+// opposed to decoding from the wire.
+func readBlockHeader(r io.Reader, pver uint32, bh *BlockHeader) error {
+	var pure PureBlockHeader
+	if err := pure.deserialize(r); err != nil {
+		return err
+	}
+	bh.Version = pure.Version
+	bh.PrevBlock = pure.PrevBlock
+	bh.MerkleRoot = pure.MerkleRoot
+	bh.Timestamp = pure.Timestamp
+	bh.Bits = pure.Bits
+	bh.Nonce = pure.Nonce
+
+	if !IsAuxPoWBlockVersion(bh.Version) {
+		bh.AuxData = nil
+		return nil
+	}
+
+	bh.AuxData = new(AuxBlockHeader)
+	return readAuxBlockHeader(r, pver, bh.AuxData)
+}
+
+// writeBlockHeader writes a bitcoin block header to w, including its
+// AuxPoW commitment when bh.Version signals merged mining. See Serialize
+// for encoding block headers to be stored to disk, such as in a database,
+// as opposed to encoding for the wire.
+func writeBlockHeader(w io.Writer, pver uint32, bh *BlockHeader) error {
+	pure := bh.ToPure()
+	if err := pure.serialize(w); err != nil {
+		return err
+	}
+
+	if !IsAuxPoWBlockVersion(bh.Version) || bh.AuxData == nil {
+		return nil
+	}
+
+	return writeAuxBlockHeader(w, pver, bh.AuxData)
+}
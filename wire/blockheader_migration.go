@@ -0,0 +1,53 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import "io"
+
+// ReadLegacyBlockHeader reads a block header in the pre-split on-disk
+// format, where every record -- regardless of Version -- was followed by a
+// full AuxBlockHeader payload rather than one gated on
+// IsAuxPoWBlockVersion. It exists solely to support migrating existing
+// block index entries to the gated format BlockHeader.Deserialize now
+// expects; new code should use Deserialize instead.
+func ReadLegacyBlockHeader(r io.Reader, pver uint32) (*BlockHeader, error) {
+	var pure PureBlockHeader
+	if err := pure.deserialize(r); err != nil {
+		return nil, err
+	}
+	bh := &BlockHeader{
+		Version:    pure.Version,
+		PrevBlock:  pure.PrevBlock,
+		MerkleRoot: pure.MerkleRoot,
+		Timestamp:  pure.Timestamp,
+		Bits:       pure.Bits,
+		Nonce:      pure.Nonce,
+	}
+
+	aux := new(AuxBlockHeader)
+	if err := readAuxBlockHeader(r, pver, aux); err != nil {
+		return nil, err
+	}
+
+	// Legacy records wrote the AuxPoW payload unconditionally; only keep
+	// it if the header's version actually signals merged mining.
+	if IsAuxPoWBlockVersion(bh.Version) {
+		bh.AuxData = aux
+	}
+
+	return bh, nil
+}
+
+// MigrateBlockHeaderRecord re-encodes a single on-disk block header record
+// from the legacy format ReadLegacyBlockHeader reads into the gated format
+// BlockHeader.Serialize writes, so existing block index entries can be
+// rewritten in place during an upgrade.
+func MigrateBlockHeaderRecord(r io.Reader, w io.Writer, pver uint32) error {
+	bh, err := ReadLegacyBlockHeader(r, pver)
+	if err != nil {
+		return err
+	}
+	return writeBlockHeader(w, pver, bh)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+// writeLegacyBlockHeader writes bh in the pre-split on-disk format, where
+// the AuxPoW payload always follows the pure header regardless of version,
+// so tests can exercise ReadLegacyBlockHeader without a real legacy fixture.
+func writeLegacyBlockHeader(t *testing.T, bh *BlockHeader) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	pure := bh.ToPure()
+	if err := pure.serialize(&buf); err != nil {
+		t.Fatalf("serialize() error = %v", err)
+	}
+
+	aux := bh.AuxData
+	if aux == nil {
+		aux = &AuxBlockHeader{}
+	}
+	if err := writeAuxBlockHeader(&buf, 0, aux); err != nil {
+		t.Fatalf("writeAuxBlockHeader() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestReadLegacyBlockHeaderDropsAuxDataForOrdinaryVersion(t *testing.T) {
+	bh := &BlockHeader{Version: 2}
+	raw := writeLegacyBlockHeader(t, bh)
+
+	got, err := ReadLegacyBlockHeader(bytes.NewReader(raw), 0)
+	if err != nil {
+		t.Fatalf("ReadLegacyBlockHeader() error = %v", err)
+	}
+	if got.AuxData != nil {
+		t.Fatalf("ReadLegacyBlockHeader() kept AuxData for a non-AuxPoW version")
+	}
+}
+
+func TestReadLegacyBlockHeaderKeepsAuxDataForAuxPoWVersion(t *testing.T) {
+	bh := &BlockHeader{
+		Version: minAuxPowBlockVersion | blockVersionAuxPowFlag,
+	}
+	raw := writeLegacyBlockHeader(t, bh)
+
+	got, err := ReadLegacyBlockHeader(bytes.NewReader(raw), 0)
+	if err != nil {
+		t.Fatalf("ReadLegacyBlockHeader() error = %v", err)
+	}
+	if got.AuxData == nil {
+		t.Fatalf("ReadLegacyBlockHeader() dropped AuxData for an AuxPoW version")
+	}
+}
+
+func TestMigrateBlockHeaderRecordRoundTrips(t *testing.T) {
+	bh := &BlockHeader{Version: 2}
+	legacy := writeLegacyBlockHeader(t, bh)
+
+	var migrated bytes.Buffer
+	if err := MigrateBlockHeaderRecord(bytes.NewReader(legacy), &migrated, 0); err != nil {
+		t.Fatalf("MigrateBlockHeaderRecord() error = %v", err)
+	}
+
+	if migrated.Len() != MaxBlockHeaderPayload {
+		t.Fatalf("migrated record length = %d, want %d (AuxPoW payload should have been dropped)",
+			migrated.Len(), MaxBlockHeaderPayload)
+	}
+
+	var got BlockHeader
+	if err := got.Deserialize(bytes.NewReader(migrated.Bytes())); err != nil {
+		t.Fatalf("Deserialize() of migrated record error = %v", err)
+	}
+	if got.Version != bh.Version {
+		t.Fatalf("migrated record Version = %v, want %v", got.Version, bh.Version)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2013-2016 The btcsuite developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBlockHeaderAuxPoWGating(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    int32
+		wantAuxPoW bool
+	}{
+		{
+			name:       "ordinary block version",
+			version:    2,
+			wantAuxPoW: false,
+		},
+		{
+			name:       "flag set but below minimum version is not auxpow",
+			version:    blockVersionAuxPowFlag,
+			wantAuxPoW: false,
+		},
+		{
+			name:       "dogecoin auxpow version",
+			version:    minAuxPowBlockVersion | blockVersionAuxPowFlag,
+			wantAuxPoW: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsAuxPoWBlockVersion(tc.version); got != tc.wantAuxPoW {
+				t.Fatalf("IsAuxPoWBlockVersion(%#x) = %v, want %v", tc.version, got, tc.wantAuxPoW)
+			}
+
+			h := &BlockHeader{Version: tc.version}
+			if tc.wantAuxPoW {
+				h.AuxData = &AuxBlockHeader{}
+			}
+
+			var buf bytes.Buffer
+			if err := writeBlockHeader(&buf, 0, h); err != nil {
+				t.Fatalf("writeBlockHeader() error = %v", err)
+			}
+
+			if tc.wantAuxPoW && buf.Len() <= MaxBlockHeaderPayload {
+				t.Fatalf("expected AuxPoW payload to be written past the pure header, got %d bytes", buf.Len())
+			}
+			if !tc.wantAuxPoW && buf.Len() != MaxBlockHeaderPayload {
+				t.Fatalf("expected exactly the pure header to be written, got %d bytes, want %d", buf.Len(), MaxBlockHeaderPayload)
+			}
+		})
+	}
+}
+
+func TestBlockHeaderBlockHashIgnoresAuxData(t *testing.T) {
+	h := &BlockHeader{
+		Version: minAuxPowBlockVersion | blockVersionAuxPowFlag,
+	}
+
+	withoutAux := h.BlockHash()
+
+	h.AuxData = &AuxBlockHeader{}
+	withAux := h.BlockHash()
+
+	if withoutAux != withAux {
+		t.Fatalf("BlockHash() changed when AuxData was attached; it must only hash the pure header")
+	}
+}